@@ -0,0 +1,147 @@
+package portfolio
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+)
+
+var yearRe = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+// ResolveLiveTicker acha, no Payload ao vivo do scraper, a linha que
+// corresponde a um ticker de transação como "Tesouro Selic 2029": infere a
+// base (LFT, LTN, NTN-B, ...) a partir do título igual o scraper faz pro
+// CSV do TD, acha o ano mencionado no texto e casa com o primeiro DataRow
+// dessa base cujo vencimento cai nesse ano.
+func ResolveLiveTicker(txTicker string, data []scraper.DataRow) (scraper.DataRow, bool) {
+	base := scraper.InferTickerBaseFromTituloTD(txTicker)
+
+	year := yearRe.FindString(txTicker)
+	if year == "" {
+		return scraper.DataRow{}, false
+	}
+
+	baseFields := strings.Fields(base)
+	for _, row := range data {
+		fields := strings.Fields(row.Ticker)
+		if len(fields) <= len(baseFields) {
+			continue
+		}
+		if !equalFields(fields[:len(baseFields)], baseFields) {
+			continue
+		}
+		// o token logo depois da base tem que ser o vencimento, senão a base
+		// "NTN-B" casaria também com "NTN-B P <vencimento>".
+		if strings.HasPrefix(fields[len(baseFields)], year+"-") {
+			return row, true
+		}
+	}
+	return scraper.DataRow{}, false
+}
+
+// equalFields compara duas fatias de tokens posição a posição, usado pra
+// casar a base do ticker como tokens inteiros (ex.: "NTN-B") em vez de um
+// prefixo de string cru, que colidiria "NTN-B" com "NTN-B P".
+func equalFields(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// PositionSummary é o par posição/preço-ao-vivo usado pra montar o
+// output/portfolio.json: abre com os campos de BuildPositions e soma o
+// cruzamento com o Payload atual e o enquadramento de IR.
+type PositionSummary struct {
+	Position
+	LiveTicker   string  `json:"live_ticker,omitempty"`
+	LivePrice    float64 `json:"live_price"`
+	LiveYield    float64 `json:"live_yield"`
+	UnrealizedPL float64 `json:"unrealized_pl"`
+	IR           IRInfo  `json:"imposto_renda"`
+
+	// YieldDeltaVsPurchase é LiveYield/100 - AvgPurchaseYield, em decimal
+	// (LiveYield vem do scraper em pontos percentuais, ex.: 10.5 pra 10,5%).
+	// Só sai preenchido quando a posição tem AvgPurchaseYield != 0, ou seja,
+	// quando as compras informaram a coluna opcional purchase_yield do CSV
+	// de transações; do contrário fica 0 porque não há yield de compra pra
+	// comparar (não confundir com "yield não mudou desde a compra").
+	YieldDeltaVsPurchase float64 `json:"yield_delta_vs_purchase,omitempty"`
+}
+
+// Summarize cruza cada Position contra o Payload ao vivo e calcula o
+// enquadramento de IR de renda fixa, relativo a asOf.
+func Summarize(positions map[string]*Position, live []scraper.DataRow, asOf time.Time) []PositionSummary {
+	out := make([]PositionSummary, 0, len(positions))
+	for _, pos := range positions {
+		summary := PositionSummary{Position: *pos}
+
+		if row, ok := ResolveLiveTicker(pos.Ticker, live); ok {
+			summary.LiveTicker = row.Ticker
+			summary.LivePrice = row.PrecoAtual
+			summary.LiveYield = row.YieldAtual
+			summary.UnrealizedPL = (row.PrecoAtual - pos.AvgCost) * pos.Quantity
+			if pos.AvgPurchaseYield != 0 {
+				summary.YieldDeltaVsPurchase = row.YieldAtual/100 - pos.AvgPurchaseYield
+			}
+		}
+
+		if oldest, ok := pos.OldestOpenLotDate(); ok {
+			summary.IR = classifyIR(oldest, asOf)
+		}
+
+		out = append(out, summary)
+	}
+	return out
+}
+
+// IRInfo descreve o enquadramento de Imposto de Renda de renda fixa pra uma
+// posição, pela tabela regressiva: 22,5% até 180 dias, 20% de 181 a 360,
+// 17,5% de 361 a 720 e 15% acima de 720.
+type IRInfo struct {
+	HeldDays       int     `json:"held_days"`
+	RatePercent    float64 `json:"rate_percent"`
+	HigherBracket  bool    `json:"higher_bracket"` // true se heldDays < 720; com exatamente 720 dias a alíquota ainda é 17,5%, mas o flag já sai false
+	DaysToNextTier int     `json:"days_to_next_tier,omitempty"`
+}
+
+func classifyIR(since, asOf time.Time) IRInfo {
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	heldDays := int(asOf.Sub(since).Hours() / 24)
+
+	var rate float64
+	var nextTierAt int
+	switch {
+	case heldDays <= 180:
+		rate = 22.5
+		nextTierAt = 181
+	case heldDays <= 360:
+		rate = 20
+		nextTierAt = 361
+	case heldDays <= 720:
+		rate = 17.5
+		nextTierAt = 721
+	default:
+		rate = 15
+		nextTierAt = 0
+	}
+
+	info := IRInfo{
+		HeldDays:      heldDays,
+		RatePercent:   rate,
+		HigherBracket: heldDays < 720,
+	}
+	if nextTierAt > 0 {
+		info.DaysToNextTier = nextTierAt - heldDays
+	}
+	return info
+}
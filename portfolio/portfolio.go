@@ -0,0 +1,254 @@
+// Package portfolio ingere o histórico de transações do usuário, calcula o
+// custo médio por FIFO partição por ticker e cruza as posições abertas
+// contra o Payload ao vivo do scraper pra obter P&L não realizado.
+package portfolio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+// Transaction é uma linha do CSV de transações do usuário:
+// date;ticker;side(buy/sell);quantity;price;fees[;purchase_yield]
+// A 7ª coluna, purchase_yield, é opcional (linhas antigas não precisam dela)
+// e guarda a taxa contratada na compra, em decimal (ex.: 0.105 pra 10,5%a.a.),
+// usada só pra calcular o delta de yield contra o yield atual do título.
+type Transaction struct {
+	Date          time.Time
+	Ticker        string // como o usuário escreveu, ex.: "Tesouro Selic 2029"
+	Side          Side
+	Quantity      float64
+	Price         float64
+	Fees          float64
+	PurchaseYield float64 // 0 quando a coluna não foi informada
+}
+
+// ParseTransactionsCSV lê o CSV de transações do usuário (separado por ';',
+// igual ao CSV que o próprio scraper consome do TD).
+func ParseTransactionsCSV(path string) ([]Transaction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro abrindo %s: %w", path, err)
+	}
+	defer f.Close()
+
+	rd := csv.NewReader(f)
+	rd.Comma = ';'
+	rd.FieldsPerRecord = -1
+
+	all, err := rd.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo csv de transações: %w", err)
+	}
+	if len(all) < 2 {
+		return nil, fmt.Errorf("%s está vazio ou sem linhas de dados", path)
+	}
+
+	var out []Transaction
+	for i, rec := range all[1:] {
+		if len(rec) < 6 {
+			return nil, fmt.Errorf("linha %d: esperava 6 colunas (date;ticker;side;quantity;price;fees), achei %d", i+2, len(rec))
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: data inválida (use YYYY-MM-DD): %w", i+2, err)
+		}
+
+		side := Side(strings.ToLower(strings.TrimSpace(rec[2])))
+		if side != Buy && side != Sell {
+			return nil, fmt.Errorf("linha %d: side deve ser buy ou sell, achei %q", i+2, rec[2])
+		}
+
+		quantity, err := strconv.ParseFloat(strings.TrimSpace(rec[3]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: quantity inválida: %w", i+2, err)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(rec[4]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: price inválido: %w", i+2, err)
+		}
+		fees, err := strconv.ParseFloat(strings.TrimSpace(rec[5]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: fees inválido: %w", i+2, err)
+		}
+
+		var purchaseYield float64
+		if len(rec) >= 7 && strings.TrimSpace(rec[6]) != "" {
+			purchaseYield, err = strconv.ParseFloat(strings.TrimSpace(rec[6]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("linha %d: purchase_yield inválido: %w", i+2, err)
+			}
+		}
+
+		out = append(out, Transaction{
+			Date:          date,
+			Ticker:        strings.TrimSpace(rec[1]),
+			Side:          side,
+			Quantity:      quantity,
+			Price:         price,
+			Fees:          fees,
+			PurchaseYield: purchaseYield,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Date.Before(out[j].Date) })
+	return out, nil
+}
+
+// lot é um lote de compra ainda (parcialmente) aberto, na ordem FIFO.
+type lot struct {
+	date     time.Time
+	quantity float64
+	price    float64
+	yield    float64 // PurchaseYield da transação de compra que abriu o lote
+}
+
+// ClosedLot é o resultado de uma venda que consumiu (total ou parcialmente)
+// um lote de compra anterior.
+type ClosedLot struct {
+	OpenDate   time.Time `json:"open_date"`
+	CloseDate  time.Time `json:"close_date"`
+	Quantity   float64   `json:"quantity"`
+	BuyPrice   float64   `json:"buy_price"`
+	SellPrice  float64   `json:"sell_price"`
+	RealizedPL float64   `json:"realized_pl"`
+	HeldDays   int       `json:"held_days"`
+}
+
+// Position é o resultado do FIFO pra um ticker: lotes ainda abertos, custo
+// médio e o histórico de lotes fechados com o P&L realizado.
+type Position struct {
+	Ticker     string      `json:"ticker"`
+	Quantity   float64     `json:"quantity"`
+	AvgCost    float64     `json:"avg_cost"`
+	RealizedPL float64     `json:"realized_pl"`
+	ClosedLots []ClosedLot `json:"closed_lots,omitempty"`
+
+	// AvgPurchaseYield é a média (ponderada por quantidade) do
+	// PurchaseYield dos lotes ainda abertos, 0 quando nenhuma transação de
+	// compra informou essa coluna opcional do CSV.
+	AvgPurchaseYield float64 `json:"avg_purchase_yield,omitempty"`
+
+	openLots []lot
+}
+
+// BuildPositions particiona as transações por Ticker e aplica FIFO em cada
+// partição, na ordem cronológica. asOf, se não-zero, ignora transações
+// depois dessa data (simula "como estava o portfólio em tal dia").
+func BuildPositions(transactions []Transaction, asOf time.Time) (map[string]*Position, error) {
+	positions := make(map[string]*Position)
+
+	for _, tx := range transactions {
+		if !asOf.IsZero() && tx.Date.After(asOf) {
+			continue
+		}
+
+		pos, ok := positions[tx.Ticker]
+		if !ok {
+			pos = &Position{Ticker: tx.Ticker}
+			positions[tx.Ticker] = pos
+		}
+
+		switch tx.Side {
+		case Buy:
+			// Custo do lote inclui a taxa de compra rateada por unidade, pra
+			// não subestimar o custo básico (e, por consequência, superestimar
+			// o P&L realizado e não realizado).
+			costPerUnit := tx.Price
+			if tx.Quantity != 0 {
+				costPerUnit += tx.Fees / tx.Quantity
+			}
+			pos.openLots = append(pos.openLots, lot{date: tx.Date, quantity: tx.Quantity, price: costPerUnit, yield: tx.PurchaseYield})
+			pos.Quantity += tx.Quantity
+		case Sell:
+			remaining := tx.Quantity
+			for remaining > 0 {
+				if len(pos.openLots) == 0 {
+					return nil, fmt.Errorf("ticker %q: venda de %.4f em %s sem lotes suficientes em aberto", tx.Ticker, tx.Quantity, tx.Date.Format("2006-01-02"))
+				}
+
+				head := &pos.openLots[0]
+				qty := remaining
+				if head.quantity < qty {
+					qty = head.quantity
+				}
+
+				realized := (tx.Price-head.price)*qty - tx.Fees*(qty/tx.Quantity)
+				pos.ClosedLots = append(pos.ClosedLots, ClosedLot{
+					OpenDate:   head.date,
+					CloseDate:  tx.Date,
+					Quantity:   qty,
+					BuyPrice:   head.price,
+					SellPrice:  tx.Price,
+					RealizedPL: realized,
+					HeldDays:   int(tx.Date.Sub(head.date).Hours() / 24),
+				})
+				pos.RealizedPL += realized
+
+				head.quantity -= qty
+				remaining -= qty
+				pos.Quantity -= qty
+				if head.quantity == 0 {
+					pos.openLots = pos.openLots[1:]
+				}
+			}
+		}
+	}
+
+	for _, pos := range positions {
+		pos.AvgCost = averageCost(pos.openLots)
+		pos.AvgPurchaseYield = averageYield(pos.openLots)
+	}
+
+	return positions, nil
+}
+
+func averageCost(lots []lot) float64 {
+	var totalQty, totalCost float64
+	for _, l := range lots {
+		totalQty += l.quantity
+		totalCost += l.quantity * l.price
+	}
+	if totalQty == 0 {
+		return 0
+	}
+	return totalCost / totalQty
+}
+
+// averageYield pondera o PurchaseYield pela quantidade, igual averageCost.
+// Lotes sem PurchaseYield informado (0) entram na média como 0, então o
+// resultado só é confiável quando todas as compras da posição informaram a
+// coluna opcional do CSV.
+func averageYield(lots []lot) float64 {
+	var totalQty, totalYield float64
+	for _, l := range lots {
+		totalQty += l.quantity
+		totalYield += l.quantity * l.yield
+	}
+	if totalQty == 0 {
+		return 0
+	}
+	return totalYield / totalQty
+}
+
+// OldestOpenLotDate devolve a data do lote em aberto mais antigo, usada pra
+// estimar o tempo de permanência da posição pro cálculo de IR.
+func (p *Position) OldestOpenLotDate() (time.Time, bool) {
+	if len(p.openLots) == 0 {
+		return time.Time{}, false
+	}
+	return p.openLots[0].date, true
+}
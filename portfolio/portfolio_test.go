@@ -0,0 +1,119 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+)
+
+func mustDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t.Fatalf("data inválida %q: %v", s, err)
+	}
+	return ts
+}
+
+func TestBuildPositionsFoldsBuyFeesIntoCost(t *testing.T) {
+	txs := []Transaction{
+		{Date: mustDate(t, "2024-01-01"), Ticker: "Tesouro Selic 2029", Side: Buy, Quantity: 10, Price: 100, Fees: 5},
+	}
+
+	positions, err := BuildPositions(txs, time.Time{})
+	if err != nil {
+		t.Fatalf("BuildPositions erro inesperado: %v", err)
+	}
+
+	pos, ok := positions["Tesouro Selic 2029"]
+	if !ok {
+		t.Fatalf("posição não encontrada")
+	}
+
+	// custo esperado: (100*10 + 5) / 10 = 100.5
+	const want = 100.5
+	if pos.AvgCost != want {
+		t.Fatalf("AvgCost = %v, want %v", pos.AvgCost, want)
+	}
+}
+
+func TestBuildPositionsPartialSellApportionsFees(t *testing.T) {
+	txs := []Transaction{
+		{Date: mustDate(t, "2024-01-01"), Ticker: "Tesouro Selic 2029", Side: Buy, Quantity: 10, Price: 100},
+		{Date: mustDate(t, "2024-02-01"), Ticker: "Tesouro Selic 2029", Side: Sell, Quantity: 4, Price: 110, Fees: 2},
+	}
+
+	positions, err := BuildPositions(txs, time.Time{})
+	if err != nil {
+		t.Fatalf("BuildPositions erro inesperado: %v", err)
+	}
+
+	pos := positions["Tesouro Selic 2029"]
+	if len(pos.ClosedLots) != 1 {
+		t.Fatalf("esperava 1 ClosedLot, achei %d", len(pos.ClosedLots))
+	}
+
+	closed := pos.ClosedLots[0]
+	// (110-100)*4 - 2*(4/4) = 40 - 2 = 38
+	const wantRealized = 38.0
+	if closed.RealizedPL != wantRealized {
+		t.Fatalf("RealizedPL = %v, want %v", closed.RealizedPL, wantRealized)
+	}
+	if pos.Quantity != 6 {
+		t.Fatalf("Quantity restante = %v, want 6", pos.Quantity)
+	}
+}
+
+func TestBuildPositionsSellWithoutEnoughLotsErrors(t *testing.T) {
+	txs := []Transaction{
+		{Date: mustDate(t, "2024-01-01"), Ticker: "Tesouro Selic 2029", Side: Sell, Quantity: 1, Price: 100},
+	}
+
+	if _, err := BuildPositions(txs, time.Time{}); err == nil {
+		t.Fatalf("esperava erro ao vender sem lotes em aberto")
+	}
+}
+
+func TestBuildPositionsAsOfIgnoresLaterTransactions(t *testing.T) {
+	txs := []Transaction{
+		{Date: mustDate(t, "2024-01-01"), Ticker: "Tesouro Selic 2029", Side: Buy, Quantity: 10, Price: 100},
+		{Date: mustDate(t, "2024-06-01"), Ticker: "Tesouro Selic 2029", Side: Buy, Quantity: 5, Price: 110},
+	}
+
+	positions, err := BuildPositions(txs, mustDate(t, "2024-03-01"))
+	if err != nil {
+		t.Fatalf("BuildPositions erro inesperado: %v", err)
+	}
+
+	pos := positions["Tesouro Selic 2029"]
+	if pos.Quantity != 10 {
+		t.Fatalf("Quantity = %v, want 10 (compra de junho deveria ser ignorada)", pos.Quantity)
+	}
+}
+
+func TestClassifyIRBrackets(t *testing.T) {
+	asOf := mustDate(t, "2025-01-01")
+
+	tests := []struct {
+		name          string
+		since         string
+		wantRate      float64
+		wantHigherBkt bool
+	}{
+		{name: "dentro de 180 dias", since: "2024-10-01", wantRate: 22.5, wantHigherBkt: true},
+		{name: "entre 181 e 360 dias", since: "2024-03-01", wantRate: 20, wantHigherBkt: true},
+		{name: "entre 361 e 720 dias", since: "2023-06-01", wantRate: 17.5, wantHigherBkt: true},
+		{name: "acima de 720 dias", since: "2022-01-01", wantRate: 15, wantHigherBkt: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := classifyIR(mustDate(t, tt.since), asOf)
+			if info.RatePercent != tt.wantRate {
+				t.Fatalf("RatePercent = %v, want %v", info.RatePercent, tt.wantRate)
+			}
+			if info.HigherBracket != tt.wantHigherBkt {
+				t.Fatalf("HigherBracket = %v, want %v", info.HigherBracket, tt.wantHigherBkt)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+// Package scraper contém o pipeline de busca, parse e montagem do Payload
+// do Tesouro Direto. É usado tanto pelo CLI one-shot quanto pelo modo -serve,
+// então evita qualquer coisa específica de CLI (flags, os.Exit, etc).
+package scraper
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+const URL_RENDIMENTO_TITULOS = "https://www.tesourodireto.com.br/produtos/dados-sobre-titulos/rendimento-dos-titulos"
+
+const URL_RESGATAR = "https://www.tesourodireto.com.br/documents/d/guest/rendimento-resgatar-csv?download=true"
+
+// ===== Output schema =====
+
+type Meta struct {
+	// Source lista as fontes consultadas nesta rodada (ex.: ["TD_Scrape"],
+	// ou ["td_resgatar", "anbima"] quando vem de um Payload mesclado pelo
+	// pacote sources).
+	Source            []string `json:"source"`
+	SourceURL         string   `json:"source_url"`
+	LastRunAt         string   `json:"last_run_at"`
+	LastPriceChangeAt string   `json:"last_price_change_at"`
+	Rows              int      `json:"rows"`
+
+	// PrevPriceChangeAt e ChangedRows são preenchidos por quem chama Run
+	// depois de comparar o Payload contra o histórico na storage (ficam
+	// zerados aqui porque o pipeline de scrape, por si só, não guarda
+	// estado entre rodadas).
+	PrevPriceChangeAt string `json:"prev_price_change_at,omitempty"`
+	ChangedRows       int    `json:"changed_rows"`
+}
+
+type DataRow struct {
+	Ticker     string  `json:"Ticker"`
+	PrecoAtual float64 `json:"Preco_Atual"`
+	YieldAtual float64 `json:"Yield_Atual"`
+	// Titulo preserva o nome do título como veio do CSV (ex.: "Tesouro
+	// Selic 2029"), pra quem precisa filtrar/exibir pelo texto original em
+	// vez do ticker normalizado — é o que -contains e ?contains= casam.
+	Titulo string `json:"titulo,omitempty"`
+	// Source identifica de qual fonte essa row veio (ex.: "td_resgatar",
+	// "td_comprar", "anbima"). Vazio quando o Payload tem uma única fonte
+	// implícita (o caso comum do Run one-shot).
+	Source string `json:"source,omitempty"`
+}
+
+type Payload struct {
+	Meta Meta      `json:"meta"`
+	Data []DataRow `json:"data"`
+}
+
+// ===== Raw CSV row =====
+
+type ResgateRow struct {
+	Titulo           string
+	RendimentoAnual  string
+	PrecoResgate     float64
+	VencimentoTitulo string
+	RawPrecoResgate  string
+}
+
+// NewClient monta o *req.Client já com impersonation/headers/timeout padrão e
+// faz o warm-up na página de rendimento pra ganhar cookies/sessão antes de
+// baixar o CSV. O client devolvido pode (e deve) ser reaproveitado entre
+// chamadas a Run, tanto no one-shot quanto no polling do -serve.
+func NewClient() *req.Client {
+	client := req.C().
+		// Impersona um browser comum (Chrome recente)
+		ImpersonateChrome().
+		// Timeouts básicos
+		SetTimeout(15 * time.Second).
+		// Headers típicos de browser
+		SetCommonHeaders(map[string]string{
+			"Accept":          "text/html,application/json;q=0.9,*/*;q=0.8",
+			"Accept-Language": "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7",
+			"Cache-Control":   "no-cache",
+		})
+
+	// Warm-up: visita a página HTML pra ganhar cookies/sessão antes do CSV
+	_, _ = client.R().
+		SetHeader("Accept", "text/html,*/*;q=0.8").
+		Get(URL_RENDIMENTO_TITULOS)
+
+	return client
+}
+
+// Run executa o pipeline completo (download do CSV, parse e montagem do
+// Payload), reaproveitando o client passado. contains filtra linhas cujo
+// título contém esse texto (case-insensitive); vazio não filtra nada.
+func Run(client *req.Client, contains string) (Payload, error) {
+	resp, err := client.R().
+		SetHeader("Referer", URL_RENDIMENTO_TITULOS).
+		Get(URL_RESGATAR)
+	if err != nil {
+		return Payload{}, fmt.Errorf("erro ao baixar CSV: %w", err)
+	}
+	if !resp.IsSuccessState() {
+		return Payload{}, fmt.Errorf("HTTP %d ao baixar CSV", resp.GetStatusCode())
+	}
+	body := resp.String()
+
+	rows, err := parseResgateCSV(body)
+	if err != nil {
+		return Payload{}, fmt.Errorf("erro ao parsear CSV: %w", err)
+	}
+
+	if contains != "" {
+		rows = filterContains(rows, contains)
+	}
+
+	runTS := nowSPISO()
+
+	lastPriceChangeAt, _ := fetchLastMarketPricingDate(client)
+
+	data := make([]DataRow, 0, len(rows))
+	for _, r := range rows {
+		vencYMD := ParsePtBrDateToYMD(r.VencimentoTitulo)
+		if vencYMD == "" {
+			continue
+		}
+
+		base := InferTickerBaseFromTituloTD(r.Titulo)
+		ticker := fmt.Sprintf("%s %s", base, vencYMD)
+
+		yld := ParseYieldPercentToDecimal(r.RendimentoAnual)
+		// Se não conseguir parsear yield, ainda assim manda o preço (yield=0)
+		data = append(data, DataRow{
+			Ticker:     ticker,
+			PrecoAtual: r.PrecoResgate,
+			YieldAtual: yld,
+			Titulo:     r.Titulo,
+		})
+	}
+
+	return Payload{
+		Meta: Meta{
+			Source:            []string{"TD_Scrape"},
+			SourceURL:         URL_RESGATAR,
+			LastRunAt:         runTS,             // sempre atualiza
+			LastPriceChangeAt: lastPriceChangeAt, // TODO: preencher depois com "última mudança de preço"
+			Rows:              len(data),
+		},
+		Data: data,
+	}, nil
+}
+
+func fetchLastMarketPricingDate(client *req.Client) (string, error) {
+	resp, err := client.R().
+		SetHeader("Accept-Language", "pt-BR,pt;q=0.9").
+		Get(URL_RENDIMENTO_TITULOS)
+	if err != nil {
+		return "", err
+	}
+	if !resp.IsSuccessState() {
+		return "", fmt.Errorf("HTTP %d ao buscar página de rendimento dos títulos", resp.GetStatusCode())
+	}
+
+	html := resp.String()
+
+	// A página renderiza o <p class="lastMarketPricingDate"></p> vazio e injeta o valor via JS:
+	//   var lastMarketPricingDate = `2026-01-28T13:02:01.613`
+	raw, ok := extractJSVar(html, "lastMarketPricingDate")
+	if !ok {
+		return "", nil // melhor não quebrar o pipeline
+	}
+
+	ts, err := parseTDISO(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return ts.Format(time.RFC3339Nano), nil
+}
+
+// Extrai: var <name> = `...` (ou "..." / '...')
+func extractJSVar(html, name string) (string, bool) {
+	re := regexp.MustCompile(`(?m)\bvar\s+` + regexp.QuoteMeta(name) + `\s*=\s*(?:` +
+		"`" + `([^` + "`" + `]+)` + "`" +
+		`|"([^"]+)"|'([^']+)')`)
+	m := re.FindStringSubmatch(html)
+	if len(m) == 0 {
+		return "", false
+	}
+	for i := 1; i <= 3; i++ {
+		if m[i] != "" {
+			return strings.TrimSpace(m[i]), true
+		}
+	}
+	return "", false
+}
+
+// Ex.: "2026-01-28T13:02:01.613" (sem timezone no HTML do TD)
+func parseTDISO(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	// Se vier com timezone (Z ou +/-), tenta RFC3339 direto.
+	if strings.ContainsAny(s, "Z+-") && strings.Contains(s, "T") && strings.Count(s, ":") >= 2 {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+	}
+
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+
+	// com milissegundos
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05.000", s, loc); err == nil {
+		return t, nil
+	}
+	// sem fração
+	return time.ParseInLocation("2006-01-02T15:04:05", s, loc)
+}
+
+// ===== Helpers: time =====
+
+func nowSPISO() string {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	t := time.Now()
+	if err == nil {
+		t = t.In(loc)
+	}
+	return t.Truncate(time.Second).Format(time.RFC3339)
+}
+
+// ===== Helpers: filtering =====
+
+func filterContains(rows []ResgateRow, substr string) []ResgateRow {
+	substr = strings.ToLower(strings.TrimSpace(substr))
+	if substr == "" {
+		return rows
+	}
+	var out []ResgateRow
+	for _, r := range rows {
+		if strings.Contains(strings.ToLower(r.Titulo), substr) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ===== CSV parsing =====
+
+func parseResgateCSV(csvText string) ([]ResgateRow, error) {
+	csvText = strings.TrimSpace(csvText)
+
+	rd := csv.NewReader(strings.NewReader(csvText))
+	rd.Comma = ';'
+	rd.FieldsPerRecord = -1 // tolerante a variações
+
+	all, err := rd.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(all) < 2 {
+		return nil, errors.New("CSV vazio ou sem linhas de dados")
+	}
+
+	headers := all[0]
+	if len(headers) == 0 {
+		return nil, errors.New("CSV sem cabeçalho")
+	}
+	headers[0] = strings.TrimPrefix(headers[0], "\ufeff") // remove BOM
+
+	idxTitulo := FindHeader(headers, "Título")
+	idxRend := FindHeader(headers, "Rendimento anual do título")
+	idxPreco := FindHeader(headers, "Preço unitário de resgate")
+	idxVenc := FindHeaderContains(headers, "Vencimento")
+
+	if idxTitulo < 0 || idxRend < 0 || idxPreco < 0 || idxVenc < 0 {
+		return nil, fmt.Errorf("não achei colunas esperadas. headers=%v", headers)
+	}
+
+	var out []ResgateRow
+	for _, rec := range all[1:] {
+		if len(rec) == 0 {
+			continue
+		}
+		if max(idxTitulo, idxRend, idxPreco, idxVenc) >= len(rec) {
+			continue
+		}
+
+		titulo := strings.TrimSpace(rec[idxTitulo])
+		rend := strings.TrimSpace(rec[idxRend])
+		rawPreco := strings.TrimSpace(rec[idxPreco])
+		venc := strings.TrimSpace(rec[idxVenc])
+
+		if titulo == "" {
+			continue
+		}
+
+		preco, err := ParseBRL(rawPreco)
+		if err != nil {
+			preco = 0
+		}
+
+		out = append(out, ResgateRow{
+			Titulo:           titulo,
+			RendimentoAnual:  rend,
+			PrecoResgate:     preco,
+			VencimentoTitulo: venc,
+			RawPrecoResgate:  rawPreco,
+		})
+	}
+	return out, nil
+}
+
+func FindHeader(headers []string, want string) int {
+	for i, h := range headers {
+		if strings.TrimSpace(h) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+func FindHeaderContains(headers []string, substr string) int {
+	substr = strings.ToLower(substr)
+	for i, h := range headers {
+		if strings.Contains(strings.ToLower(strings.TrimSpace(h)), substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ===== Parsing: money, dates, yield =====
+
+func ParseBRL(s string) (float64, error) {
+	// Ex.: "R$ 1.234,56" ou "1.234,56"
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, "R$", "")
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, ".", "")  // milhar
+	s = strings.ReplaceAll(s, ",", ".") // decimal
+	return strconv.ParseFloat(s, 64)
+}
+
+func ParsePtBrDateToYMD(s string) string {
+	s = strings.TrimSpace(s)
+
+	// dd/mm/yyyy
+	if strings.Count(s, "/") == 2 {
+		parts := strings.Split(s, "/")
+		if len(parts) == 3 && len(parts[2]) == 4 {
+			dd, mm, yyyy := parts[0], parts[1], parts[2]
+			if len(dd) == 2 && len(mm) == 2 {
+				return fmt.Sprintf("%s-%s-%s", yyyy, mm, dd)
+			}
+		}
+	}
+
+	// yyyy-mm-dd (já pronto)
+	if strings.Count(s, "-") == 2 {
+		parts := strings.Split(s, "-")
+		if len(parts) == 3 && len(parts[0]) == 4 {
+			return s
+		}
+	}
+	return ""
+}
+
+// Extrai o primeiro percentual e devolve em decimal (ex.: "3,53%" => 0.0353)
+func ParseYieldPercentToDecimal(s string) float64 {
+	s = strings.TrimSpace(s)
+
+	// pega o primeiro número antes do %
+	re := regexp.MustCompile(`([0-9]+(?:,[0-9]+)?|[0-9]+(?:\.[0-9]+)?)\s*%`)
+	m := re.FindStringSubmatch(s)
+	if len(m) < 2 {
+		return 0
+	}
+
+	num := strings.ReplaceAll(m[1], ".", "") // se vier "1.234,56%" (raro)
+	num = strings.ReplaceAll(num, ",", ".")
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	return f // / 100.0 (ignoraremos a divisão por enquanto)
+}
+
+// ===== Mapping: título -> ticker base =====
+// Lógica espelhada do seu script python (com adaptações para o nome vindo do TD CSV).
+// Exportada porque o pacote portfolio também precisa mapear o nome de título
+// usado em transações do usuário (ex.: "Tesouro Selic 2029") pro ticker vivo.
+func InferTickerBaseFromTituloTD(titulo string) string {
+	t := strings.ToLower(strings.TrimSpace(titulo))
+
+	// Selic
+	if strings.Contains(t, "selic") {
+		return "LFT"
+	}
+
+	// Prefixados
+	if strings.Contains(t, "prefixado") && strings.Contains(t, "juros") {
+		return "NTN-F"
+	}
+	if strings.Contains(t, "prefixado") {
+		return "LTN"
+	}
+
+	// IPCA
+	if strings.Contains(t, "ipca") && strings.Contains(t, "juros") {
+		return "NTN-B"
+	}
+	if strings.Contains(t, "ipca") {
+		return "NTN-B P"
+	}
+
+	// Outros
+	if strings.Contains(t, "igpm") && strings.Contains(t, "juros") {
+		return "NTN-C"
+	}
+	if strings.Contains(t, "renda+") {
+		return "NTN-B1 R+"
+	}
+	if strings.Contains(t, "educa+") || strings.Contains(t, "educa") {
+		return "NTN-B1 E+"
+	}
+
+	return "TD"
+}
+
+func max(nums ...int) int {
+	m := nums[0]
+	for _, n := range nums[1:] {
+		if n > m {
+			m = n
+		}
+	}
+	return m
+}
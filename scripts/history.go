@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/FelipeQueirozC/realtime-TD/storage"
+)
+
+// runHistoryCmd implementa `td history --ticker "..." [--since ...] [--format csv|json]`.
+func runHistoryCmd(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	var (
+		ticker string
+		since  string
+		format string
+		dbPath string
+	)
+	fs.StringVar(&ticker, "ticker", "", "ticker a consultar, ex.: \"LTN 2028-01-01\" (obrigatório)")
+	fs.StringVar(&since, "since", "", "só mostra snapshots a partir desse instante (RFC3339); vazio = todo o histórico")
+	fs.StringVar(&format, "format", "json", "formato de saída: json ou csv")
+	fs.StringVar(&dbPath, "db", defaultDBPath, "caminho do banco sqlite com o histórico de snapshots")
+	fs.Parse(args)
+
+	if ticker == "" {
+		log.Fatalf("--ticker é obrigatório")
+	}
+
+	var sinceTS time.Time
+	if since != "" {
+		ts, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			log.Fatalf("--since inválido (esperado RFC3339): %v", err)
+		}
+		sinceTS = ts
+	}
+
+	store, err := storage.NewSQLiteStore(dbPath)
+	if err != nil {
+		log.Fatalf("erro abrindo storage: %v", err)
+	}
+	defer store.Close()
+
+	snapshots, err := store.History(ticker, sinceTS)
+	if err != nil {
+		log.Fatalf("erro consultando histórico: %v", err)
+	}
+
+	if err := printSnapshots(os.Stdout, snapshots, format); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// runLatestCmd implementa `td latest`, o snapshot mais recente de cada ticker.
+func runLatestCmd(args []string) {
+	fs := flag.NewFlagSet("latest", flag.ExitOnError)
+	var (
+		format string
+		dbPath string
+	)
+	fs.StringVar(&format, "format", "json", "formato de saída: json ou csv")
+	fs.StringVar(&dbPath, "db", defaultDBPath, "caminho do banco sqlite com o histórico de snapshots")
+	fs.Parse(args)
+
+	store, err := storage.NewSQLiteStore(dbPath)
+	if err != nil {
+		log.Fatalf("erro abrindo storage: %v", err)
+	}
+	defer store.Close()
+
+	snapshots, err := store.Latest()
+	if err != nil {
+		log.Fatalf("erro consultando últimos snapshots: %v", err)
+	}
+
+	if err := printSnapshots(os.Stdout, snapshots, format); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+func printSnapshots(w *os.File, snapshots []storage.Snapshot, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snapshots)
+	case "csv":
+		cw := csv.NewWriter(w)
+		defer cw.Flush()
+		if err := cw.Write([]string{"ticker", "last_price_change_at", "preco_atual", "yield_atual", "observed_at"}); err != nil {
+			return err
+		}
+		for _, snap := range snapshots {
+			record := []string{
+				snap.Ticker,
+				snap.LastPriceChangeAt,
+				strconv.FormatFloat(snap.PrecoAtual, 'f', -1, 64),
+				strconv.FormatFloat(snap.YieldAtual, 'f', -1, 64),
+				snap.ObservedAt,
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("formato desconhecido: %s (use json ou csv)", format)
+	}
+}
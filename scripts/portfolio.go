@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FelipeQueirozC/realtime-TD/portfolio"
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+)
+
+// runPortfolioCmd implementa `td portfolio --tx transactions.csv [--as-of date]`:
+// lê as transações do usuário, aplica FIFO por ticker, cruza as posições
+// abertas contra o Payload ao vivo e escreve output/portfolio.json.
+func runPortfolioCmd(args []string) {
+	fs := flag.NewFlagSet("portfolio", flag.ExitOnError)
+	var (
+		txPath string
+		asOf   string
+	)
+	fs.StringVar(&txPath, "tx", "", "caminho do CSV de transações (date;ticker;side;quantity;price;fees[;purchase_yield]) (obrigatório)")
+	fs.StringVar(&asOf, "as-of", "", "ignora transações depois dessa data (YYYY-MM-DD); vazio = até hoje")
+	fs.Parse(args)
+
+	if txPath == "" {
+		log.Fatalf("--tx é obrigatório")
+	}
+
+	var asOfTS time.Time
+	if asOf != "" {
+		ts, err := time.Parse("2006-01-02", asOf)
+		if err != nil {
+			log.Fatalf("--as-of inválido (esperado YYYY-MM-DD): %v", err)
+		}
+		asOfTS = ts
+	}
+
+	transactions, err := portfolio.ParseTransactionsCSV(txPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	positions, err := portfolio.BuildPositions(transactions, asOfTS)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	client := scraper.NewClient()
+	payload, err := scraper.Run(client, "")
+	if err != nil {
+		log.Fatalf("erro buscando preços ao vivo: %v", err)
+	}
+
+	summaries := portfolio.Summarize(positions, payload.Data, asOfTS)
+
+	if err := writePortfolioJSON("output/portfolio.json", summaries); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+func writePortfolioJSON(path string, summaries []portfolio.PositionSummary) error {
+	var aggregate struct {
+		RealizedPL   float64                     `json:"realized_pl"`
+		UnrealizedPL float64                     `json:"unrealized_pl"`
+		Positions    []portfolio.PositionSummary `json:"positions"`
+	}
+	for _, s := range summaries {
+		aggregate.RealizedPL += s.RealizedPL
+		aggregate.UnrealizedPL += s.UnrealizedPL
+	}
+	aggregate.Positions = summaries
+
+	b, err := json.MarshalIndent(aggregate, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro marshal json: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("erro criando pasta output: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("erro salvando %s: %w", path, err)
+	}
+
+	fmt.Printf("Salvou em %s\n", path)
+	return nil
+}
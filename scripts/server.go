@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+	"github.com/imroc/req/v3"
+)
+
+// daemon mantém o estado do modo -serve: o Payload mais recente e os
+// contadores expostos em /metrics. O client com cookies quentes é
+// reaproveitado entre polls, mas vive fora do daemon (passado pro pollLoop).
+type daemon struct {
+	contains string
+
+	mu      sync.RWMutex
+	payload scraper.Payload
+
+	scrapeSuccessTotal  atomic.Int64
+	scrapeErrorTotal    atomic.Int64
+	lastPriceChangeUnix atomic.Int64
+}
+
+// runServer sobe o scheduler de polling e a API HTTP, e bloqueia até o
+// processo ser encerrado.
+func runServer(addr string, interval time.Duration, contains string) {
+	client := scraper.NewClient()
+
+	d := &daemon{contains: contains}
+
+	// Primeiro tick síncrono, pra já subir com dados em vez de 404 no começo.
+	d.tick(client)
+
+	go d.pollLoop(client, interval)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/titulos/", d.handleTituloByTicker)
+	mux.HandleFunc("/titulos", d.handleTitulos)
+	mux.HandleFunc("/healthz", d.handleHealthz)
+	mux.HandleFunc("/metrics", d.handleMetrics)
+
+	log.Printf("servindo em %s (poll a cada %s)", addr, interval)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatalf("erro no servidor HTTP: %v", err)
+	}
+}
+
+func (d *daemon) pollLoop(client *req.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		d.tick(client)
+	}
+}
+
+// tick roda um ciclo de scrape e só substitui o Payload guardado se
+// lastMarketPricingDate mudou desde o último tick (ou ainda não tínhamos
+// nenhum payload), evitando reemitir a mesma foto do mercado.
+func (d *daemon) tick(client *req.Client) {
+	payload, err := scraper.Run(client, d.contains)
+	if err != nil {
+		d.scrapeErrorTotal.Add(1)
+		log.Printf("erro no poll: %v", err)
+		return
+	}
+
+	d.scrapeSuccessTotal.Add(1)
+
+	d.mu.Lock()
+	changed := payload.Meta.LastPriceChangeAt != d.payload.Meta.LastPriceChangeAt
+	if changed || len(d.payload.Data) == 0 {
+		d.payload = payload
+	}
+	d.mu.Unlock()
+
+	if changed {
+		if ts, err := time.Parse(time.RFC3339Nano, payload.Meta.LastPriceChangeAt); err == nil {
+			d.lastPriceChangeUnix.Store(ts.Unix())
+		}
+	}
+}
+
+func (d *daemon) snapshot() scraper.Payload {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.payload
+}
+
+func (d *daemon) handleTitulos(w http.ResponseWriter, r *http.Request) {
+	payload := d.snapshot()
+
+	if contains := r.URL.Query().Get("contains"); contains != "" {
+		payload.Data = filterDataRowsContains(payload.Data, contains)
+		payload.Meta.Rows = len(payload.Data)
+	}
+
+	writeJSON(w, payload)
+}
+
+func (d *daemon) handleTituloByTicker(w http.ResponseWriter, r *http.Request) {
+	ticker := strings.TrimPrefix(r.URL.Path, "/titulos/")
+	if ticker == "" {
+		d.handleTitulos(w, r)
+		return
+	}
+
+	payload := d.snapshot()
+	for _, row := range payload.Data {
+		if strings.EqualFold(row.Ticker, ticker) {
+			writeJSON(w, row)
+			return
+		}
+	}
+	http.Error(w, fmt.Sprintf("ticker %q não encontrado", ticker), http.StatusNotFound)
+}
+
+func (d *daemon) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (d *daemon) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# HELP td_scrape_success_total total de polls ao CSV do TD que terminaram com sucesso\n")
+	fmt.Fprintf(w, "# TYPE td_scrape_success_total counter\n")
+	fmt.Fprintf(w, "td_scrape_success_total %d\n", d.scrapeSuccessTotal.Load())
+
+	fmt.Fprintf(w, "# HELP td_scrape_error_total total de polls ao CSV do TD que falharam\n")
+	fmt.Fprintf(w, "# TYPE td_scrape_error_total counter\n")
+	fmt.Fprintf(w, "td_scrape_error_total %d\n", d.scrapeErrorTotal.Load())
+
+	fmt.Fprintf(w, "# HELP td_last_price_change_at_seconds unix timestamp da última mudança observada em lastMarketPricingDate\n")
+	fmt.Fprintf(w, "# TYPE td_last_price_change_at_seconds gauge\n")
+	fmt.Fprintf(w, "td_last_price_change_at_seconds %d\n", d.lastPriceChangeUnix.Load())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// filterDataRowsContains filtra por substring case-insensitive no título,
+// igual a flag -contains do scraper.Run, pra GET /titulos?contains= casar os
+// mesmos resultados. Cai pro Ticker quando a fonte não preenche Titulo (ex.:
+// Anbima), pra não virar um filtro que nunca bate nada nessas linhas.
+func filterDataRowsContains(rows []scraper.DataRow, substr string) []scraper.DataRow {
+	substr = strings.ToLower(strings.TrimSpace(substr))
+	if substr == "" {
+		return rows
+	}
+	out := make([]scraper.DataRow, 0, len(rows))
+	for _, row := range rows {
+		text := row.Titulo
+		if text == "" {
+			text = row.Ticker
+		}
+		if strings.Contains(strings.ToLower(text), substr) {
+			out = append(out, row)
+		}
+	}
+	return out
+}
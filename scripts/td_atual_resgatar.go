@@ -1,435 +1,220 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/imroc/req/v3"
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+	"github.com/FelipeQueirozC/realtime-TD/sources"
+	"github.com/FelipeQueirozC/realtime-TD/storage"
 )
 
-const URL_RENDIMENTO_TITULOS = "https://www.tesourodireto.com.br/produtos/dados-sobre-titulos/rendimento-dos-titulos"
+// defaultSource é a única fonte consultada quando -sources não é informado,
+// preservando o comportamento de sempre (só o CSV de resgate do TD).
+const defaultSource = "td_resgatar"
 
-func fetchLastMarketPricingDate(client *req.Client) (string, error) {
-	resp, err := client.R().
-		SetHeader("Accept-Language", "pt-BR,pt;q=0.9").
-		Get(URL_RENDIMENTO_TITULOS)
-	if err != nil {
-		return "", err
-	}
-	if !resp.IsSuccessState() {
-		return "", fmt.Errorf("HTTP %d ao buscar página de rendimento dos títulos", resp.GetStatusCode())
-	}
-
-	html := resp.String()
+// caminho padrão do banco de histórico; -db sobrescreve.
+const defaultDBPath = "output/td_history.db"
 
-	// A página renderiza o <p class="lastMarketPricingDate"></p> vazio e injeta o valor via JS:
-	//   var lastMarketPricingDate = `2026-01-28T13:02:01.613`
-	raw, ok := extractJSVar(html, "lastMarketPricingDate")
-	if !ok {
-		return "", nil // melhor não quebrar o pipeline
-	}
-
-	ts, err := parseTDISO(raw)
-	if err != nil {
-		return "", err
-	}
-
-	return ts.Format(time.RFC3339Nano), nil
-}
-
-// Extrai: var <name> = `...` (ou "..." / '...')
-func extractJSVar(html, name string) (string, bool) {
-	re := regexp.MustCompile(`(?m)\bvar\s+` + regexp.QuoteMeta(name) + `\s*=\s*(?:` +
-		"`" + `([^` + "`" + `]+)` + "`" +
-		`|"([^"]+)"|'([^']+)')`)
-	m := re.FindStringSubmatch(html)
-	if len(m) == 0 {
-		return "", false
-	}
-	for i := 1; i <= 3; i++ {
-		if m[i] != "" {
-			return strings.TrimSpace(m[i]), true
-		}
-	}
-	return "", false
-}
-
-// Ex.: "2026-01-28T13:02:01.613" (sem timezone no HTML do TD)
-func parseTDISO(s string) (time.Time, error) {
-	s = strings.TrimSpace(s)
-
-	// Se vier com timezone (Z ou +/-), tenta RFC3339 direto.
-	if strings.ContainsAny(s, "Z+-") && strings.Contains(s, "T") && strings.Count(s, ":") >= 2 {
-		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
-			return t, nil
-		}
-		if t, err := time.Parse(time.RFC3339, s); err == nil {
-			return t, nil
+func main() {
+	// Subcomandos (history, latest, portfolio) têm suas próprias flags e não passam
+	// pelo fluxo de scrape abaixo.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "history":
+			runHistoryCmd(os.Args[2:])
+			return
+		case "latest":
+			runLatestCmd(os.Args[2:])
+			return
+		case "portfolio":
+			runPortfolioCmd(os.Args[2:])
+			return
 		}
 	}
 
-	loc, _ := time.LoadLocation("America/Sao_Paulo")
-
-	// com milissegundos
-	if t, err := time.ParseInLocation("2006-01-02T15:04:05.000", s, loc); err == nil {
-		return t, nil
-	}
-	// sem fração
-	return time.ParseInLocation("2006-01-02T15:04:05", s, loc)
-}
-
-const URL_RESGATAR = "https://www.tesourodireto.com.br/documents/d/guest/rendimento-resgatar-csv?download=true"
-
-// ===== Output schema =====
-
-type Meta struct {
-	Source            string `json:"source"`
-	SourceURL         string `json:"source_url"`
-	LastRunAt         string `json:"last_run_at"`
-	LastPriceChangeAt string `json:"last_price_change_at"`
-	Rows              int    `json:"rows"`
-}
-
-type DataRow struct {
-	Ticker     string  `json:"Ticker"`
-	PrecoAtual float64 `json:"Preco_Atual"`
-	YieldAtual float64 `json:"Yield_Atual"`
-}
-
-type Payload struct {
-	Meta Meta      `json:"meta"`
-	Data []DataRow `json:"data"`
-}
-
-// ===== Raw CSV row =====
-
-type ResgateRow struct {
-	Titulo           string
-	RendimentoAnual  string
-	PrecoResgate     float64
-	VencimentoTitulo string
-	RawPrecoResgate  string
-}
-
-func main() {
-	var contains string
+	var (
+		contains    string
+		serve       bool
+		interval    time.Duration
+		addr        string
+		dbPath      string
+		watchConfig string
+		dryRun      bool
+		sourceNames string
+		fanOut      bool
+	)
 	flag.StringVar(&contains, "contains", "", "filtra linhas cujo título contém esse texto (case-insensitive)")
+	flag.BoolVar(&serve, "serve", false, "roda como serviço residente, expondo a API HTTP em vez de rodar uma vez e sair")
+	flag.DurationVar(&interval, "interval", 30*time.Second, "intervalo de polling do CSV no modo -serve/-watch")
+	flag.StringVar(&addr, "addr", ":8080", "endereço em que a API HTTP escuta no modo -serve")
+	flag.StringVar(&dbPath, "db", defaultDBPath, "caminho do banco sqlite com o histórico de snapshots")
+	flag.StringVar(&watchConfig, "watch", "", "caminho de um arquivo YAML/JSON de regras de alerta; liga o modo -watch")
+	flag.BoolVar(&dryRun, "dry-run", false, "no modo -watch, só imprime quais regras casariam, sem esperar mudança nem notificar de verdade")
+	flag.StringVar(&sourceNames, "sources", defaultSource, "lista separada por vírgula das fontes a consultar (td_resgatar, td_comprar, anbima)")
+	flag.BoolVar(&fanOut, "fanout", false, "com mais de uma -sources, escreve um JSON por fonte em vez de mesclar tudo num Payload só")
 	flag.Parse()
 
-	client := req.C().
-		// Impersona um browser comum (Chrome recente)
-		ImpersonateChrome().
-		// Timeouts básicos
-		SetTimeout(15 * time.Second).
-		// Headers típicos de browser
-		SetCommonHeaders(map[string]string{
-			"Accept":          "text/html,application/json;q=0.9,*/*;q=0.8",
-			"Accept-Language": "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7",
-			"Cache-Control":   "no-cache",
-		})
-
-	// Warm-up: visita a página HTML pra ganhar cookies/sessão antes do CSV
-	_, _ = client.R().
-		SetHeader("Accept", "text/html,*/*;q=0.8").
-		Get(URL_RENDIMENTO_TITULOS)
-
-	// Baixa CSV como texto
-	resp, err := client.R().
-		SetHeader("Referer", URL_RENDIMENTO_TITULOS).
-		Get(URL_RESGATAR)
-	if err != nil {
-		log.Fatalf("erro ao baixar CSV: %v", err)
-	}
-	if !resp.IsSuccessState() {
-		log.Fatalf("HTTP %d ao baixar CSV", resp.GetStatusCode())
+	if watchConfig != "" {
+		runWatchCmd(watchConfig, interval, dbPath, contains, dryRun)
+		return
 	}
-	body := resp.String()
 
-	rows, err := parseResgateCSV(body)
-	if err != nil {
-		log.Fatalf("erro ao parsear CSV: %v", err)
+	if serve {
+		runServer(addr, interval, contains)
+		return
 	}
 
-	if contains != "" {
-		rows = filterContains(rows, contains)
+	names := strings.Split(sourceNames, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
 	}
 
-	runTS := nowSPISO()
-
-	lastPriceChangeAt, _ := fetchLastMarketPricingDate(client)
-
-	data := make([]DataRow, 0, len(rows))
-	for _, r := range rows {
-		vencYMD := parsePtBrDateToYMD(r.VencimentoTitulo)
-		if vencYMD == "" {
-			continue
+	if fanOut && len(names) > 1 {
+		if err := scrapeFanOut(names); err != nil {
+			log.Fatalf("%v", err)
 		}
-
-		base := inferTickerBaseFromTituloTD(r.Titulo)
-		ticker := fmt.Sprintf("%s %s", base, vencYMD)
-
-		yld := parseYieldPercentToDecimal(r.RendimentoAnual)
-		// Se não conseguir parsear yield, ainda assim manda o preço (yield=0)
-		data = append(data, DataRow{
-			Ticker:     ticker,
-			PrecoAtual: r.PrecoResgate,
-			YieldAtual: yld,
-		})
+		return
 	}
 
-	payload := Payload{
-		Meta: Meta{
-			Source:            "TD_Scrape",
-			SourceURL:         URL_RESGATAR,
-			LastRunAt:         runTS,             // sempre atualiza
-			LastPriceChangeAt: lastPriceChangeAt, // TODO: preencher depois com "última mudança de preço"
-			Rows:              len(data),
-		},
-		Data: data,
-	}
-
-	b, err := json.MarshalIndent(payload, "", "  ")
+	payload, err := scrapeAndRecord(names, contains, dbPath)
 	if err != nil {
-		log.Fatalf("erro marshal json: %v", err)
-	}
-
-	outDir := "output"
-	if err := os.MkdirAll(outDir, 0755); err != nil {
-		log.Fatalf("erro criando pasta output: %v", err)
+		log.Fatalf("%v", err)
 	}
 
-	outPath := filepath.Join(outDir, "td_realtime_resgatar.json")
-	if err := os.WriteFile(outPath, b, 0644); err != nil {
-		log.Fatalf("erro salvando json: %v", err)
+	if err := writeOutputJSON(payload); err != nil {
+		log.Fatalf("%v", err)
 	}
-
-	fmt.Printf("Salvou em %s\n", outPath)
 }
 
-// ===== Helpers: time =====
+// scrapeFanOut busca em cada fonte de names e escreve um
+// output/td_<fonte>.json por fonte, sem mesclar nada.
+func scrapeFanOut(names []string) error {
+	client := scraper.NewClient()
 
-func nowSPISO() string {
-	loc, err := time.LoadLocation("America/Sao_Paulo")
-	t := time.Now()
-	if err == nil {
-		t = t.In(loc)
+	payloads, err := sources.FanOut(context.Background(), client, names)
+	if err != nil {
+		return err
 	}
-	return t.Truncate(time.Second).Format(time.RFC3339)
-}
 
-// ===== Helpers: filtering =====
-
-func filterContains(rows []ResgateRow, substr string) []ResgateRow {
-	substr = strings.ToLower(strings.TrimSpace(substr))
-	if substr == "" {
-		return rows
-	}
-	var out []ResgateRow
-	for _, r := range rows {
-		if strings.Contains(strings.ToLower(r.Titulo), substr) {
-			out = append(out, r)
+	for name, payload := range payloads {
+		path := filepath.Join("output", fmt.Sprintf("td_%s.json", name))
+		if err := writeJSONFile(path, payload); err != nil {
+			return err
 		}
 	}
-	return out
+	return nil
 }
 
-// ===== CSV parsing =====
-
-func parseResgateCSV(csvText string) ([]ResgateRow, error) {
-	csvText = strings.TrimSpace(csvText)
+// scrapeAndRecord roda o pipeline de scrape (mesclando as fontes pedidas em
+// names), grava os snapshots observados na storage e escreve
+// output/td_changes.jsonl com as linhas cujo preço ou yield mudou desde o
+// snapshot anterior. Popula Meta.PrevPriceChangeAt e Meta.ChangedRows no
+// Payload devolvido.
+func scrapeAndRecord(names []string, contains, dbPath string) (scraper.Payload, error) {
+	client := scraper.NewClient()
 
-	rd := csv.NewReader(strings.NewReader(csvText))
-	rd.Comma = ';'
-	rd.FieldsPerRecord = -1 // tolerante a variações
-
-	all, err := rd.ReadAll()
-	if err != nil {
-		return nil, err
-	}
-	if len(all) < 2 {
-		return nil, errors.New("CSV vazio ou sem linhas de dados")
+	var payload scraper.Payload
+	var err error
+	if len(names) == 1 && names[0] == defaultSource {
+		// caminho de sempre: só o CSV de resgate, sem passar pelo pacote sources.
+		payload, err = scraper.Run(client, contains)
+	} else {
+		payload, err = sources.Merge(context.Background(), client, names)
 	}
-
-	headers := all[0]
-	if len(headers) == 0 {
-		return nil, errors.New("CSV sem cabeçalho")
+	if err != nil {
+		return payload, err
 	}
-	headers[0] = strings.TrimPrefix(headers[0], "\uFEFF") // remove BOM
-
-	idxTitulo := findHeader(headers, "Título")
-	idxRend := findHeader(headers, "Rendimento anual do título")
-	idxPreco := findHeader(headers, "Preço unitário de resgate")
-	idxVenc := findHeaderContains(headers, "Vencimento")
 
-	if idxTitulo < 0 || idxRend < 0 || idxPreco < 0 || idxVenc < 0 {
-		return nil, fmt.Errorf("não achei colunas esperadas. headers=%v", headers)
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return payload, fmt.Errorf("erro criando pasta da storage: %w", err)
 	}
 
-	var out []ResgateRow
-	for _, rec := range all[1:] {
-		if len(rec) == 0 {
-			continue
-		}
-		if max(idxTitulo, idxRend, idxPreco, idxVenc) >= len(rec) {
-			continue
-		}
-
-		titulo := strings.TrimSpace(rec[idxTitulo])
-		rend := strings.TrimSpace(rec[idxRend])
-		rawPreco := strings.TrimSpace(rec[idxPreco])
-		venc := strings.TrimSpace(rec[idxVenc])
-
-		if titulo == "" {
-			continue
-		}
-
-		preco, err := parseBRL(rawPreco)
-		if err != nil {
-			preco = 0
-		}
-
-		out = append(out, ResgateRow{
-			Titulo:           titulo,
-			RendimentoAnual:  rend,
-			PrecoResgate:     preco,
-			VencimentoTitulo: venc,
-			RawPrecoResgate:  rawPreco,
+	store, err := storage.NewSQLiteStore(dbPath)
+	if err != nil {
+		return payload, fmt.Errorf("erro abrindo storage: %w", err)
+	}
+	defer store.Close()
+
+	snapshots := make([]storage.Snapshot, 0, len(payload.Data))
+	for _, row := range payload.Data {
+		snapshots = append(snapshots, storage.Snapshot{
+			Ticker:            row.Ticker,
+			LastPriceChangeAt: payload.Meta.LastPriceChangeAt,
+			PrecoAtual:        row.PrecoAtual,
+			YieldAtual:        row.YieldAtual,
+			ObservedAt:        payload.Meta.LastRunAt,
 		})
 	}
-	return out, nil
-}
 
-func findHeader(headers []string, want string) int {
-	for i, h := range headers {
-		if strings.TrimSpace(h) == want {
-			return i
-		}
+	changes, err := storage.Diff(store, snapshots)
+	if err != nil {
+		return payload, fmt.Errorf("erro no diff contra o histórico: %w", err)
 	}
-	return -1
-}
 
-func findHeaderContains(headers []string, substr string) int {
-	substr = strings.ToLower(substr)
-	for i, h := range headers {
-		if strings.Contains(strings.ToLower(strings.TrimSpace(h)), substr) {
-			return i
+	if len(snapshots) > 0 {
+		if prev, ok, err := store.LastSnapshot(snapshots[0].Ticker); err == nil && ok {
+			payload.Meta.PrevPriceChangeAt = prev.LastPriceChangeAt
 		}
 	}
-	return -1
-}
-
-// ===== Parsing: money, dates, yield =====
+	payload.Meta.ChangedRows = len(changes)
 
-func parseBRL(s string) (float64, error) {
-	// Ex.: "R$ 1.234,56" ou "1.234,56"
-	s = strings.TrimSpace(s)
-	s = strings.ReplaceAll(s, "R$", "")
-	s = strings.TrimSpace(s)
-	s = strings.ReplaceAll(s, ".", "")  // milhar
-	s = strings.ReplaceAll(s, ",", ".") // decimal
-	return strconv.ParseFloat(s, 64)
-}
-
-func parsePtBrDateToYMD(s string) string {
-	s = strings.TrimSpace(s)
-
-	// dd/mm/yyyy
-	if strings.Count(s, "/") == 2 {
-		parts := strings.Split(s, "/")
-		if len(parts) == 3 && len(parts[2]) == 4 {
-			dd, mm, yyyy := parts[0], parts[1], parts[2]
-			if len(dd) == 2 && len(mm) == 2 {
-				return fmt.Sprintf("%s-%s-%s", yyyy, mm, dd)
-			}
-		}
+	if err := store.RecordSnapshots(snapshots); err != nil {
+		return payload, fmt.Errorf("erro gravando snapshots: %w", err)
 	}
 
-	// yyyy-mm-dd (já pronto)
-	if strings.Count(s, "-") == 2 {
-		parts := strings.Split(s, "-")
-		if len(parts) == 3 && len(parts[0]) == 4 {
-			return s
-		}
+	if err := writeChangesJSONL("output/td_changes.jsonl", changes); err != nil {
+		return payload, err
 	}
-	return ""
-}
 
-// Extrai o primeiro percentual e devolve em decimal (ex.: "3,53%" => 0.0353)
-func parseYieldPercentToDecimal(s string) float64 {
-	s = strings.TrimSpace(s)
+	return payload, nil
+}
 
-	// pega o primeiro número antes do %
-	re := regexp.MustCompile(`([0-9]+(?:,[0-9]+)?|[0-9]+(?:\.[0-9]+)?)\s*%`)
-	m := re.FindStringSubmatch(s)
-	if len(m) < 2 {
-		return 0
+func writeChangesJSONL(path string, changes []storage.Change) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("erro criando pasta output: %w", err)
 	}
 
-	num := strings.ReplaceAll(m[1], ".", "") // se vier "1.234,56%" (raro)
-	num = strings.ReplaceAll(num, ",", ".")
-	f, err := strconv.ParseFloat(num, 64)
+	f, err := os.Create(path)
 	if err != nil {
-		return 0
+		return fmt.Errorf("erro criando %s: %w", path, err)
 	}
-	return f // / 100.0 (ignoraremos a divisão por enquanto)
-}
-
-// ===== Mapping: título -> ticker base =====
-// Lógica espelhada do seu script python (com adaptações para o nome vindo do TD CSV).
-func inferTickerBaseFromTituloTD(titulo string) string {
-	t := strings.ToLower(strings.TrimSpace(titulo))
+	defer f.Close()
 
-	// Selic
-	if strings.Contains(t, "selic") {
-		return "LFT"
+	enc := json.NewEncoder(f)
+	for _, change := range changes {
+		if err := enc.Encode(change); err != nil {
+			return fmt.Errorf("erro escrevendo em %s: %w", path, err)
+		}
 	}
+	return nil
+}
 
-	// Prefixados
-	if strings.Contains(t, "prefixado") && strings.Contains(t, "juros") {
-		return "NTN-F"
-	}
-	if strings.Contains(t, "prefixado") {
-		return "LTN"
-	}
+func writeOutputJSON(payload scraper.Payload) error {
+	return writeJSONFile(filepath.Join("output", "td_realtime_resgatar.json"), payload)
+}
 
-	// IPCA
-	if strings.Contains(t, "ipca") && strings.Contains(t, "juros") {
-		return "NTN-B"
-	}
-	if strings.Contains(t, "ipca") {
-		return "NTN-B P"
+func writeJSONFile(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro marshal json: %w", err)
 	}
 
-	// Outros
-	if strings.Contains(t, "igpm") && strings.Contains(t, "juros") {
-		return "NTN-C"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("erro criando pasta output: %w", err)
 	}
-	if strings.Contains(t, "renda+") {
-		return "NTN-B1 R+"
-	}
-	if strings.Contains(t, "educa+") || strings.Contains(t, "educa") {
-		return "NTN-B1 E+"
-	}
-
-	return "TD"
-}
 
-func max(nums ...int) int {
-	m := nums[0]
-	for _, n := range nums[1:] {
-		if n > m {
-			m = n
-		}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("erro salvando %s: %w", path, err)
 	}
-	return m
+
+	fmt.Printf("Salvou em %s\n", path)
+	return nil
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+	"github.com/FelipeQueirozC/realtime-TD/storage"
+	"github.com/FelipeQueirozC/realtime-TD/watch"
+	"github.com/imroc/req/v3"
+)
+
+// runWatchCmd implementa o modo `-watch config.yaml`: a cada poll do CSV,
+// avalia as regras do arquivo de config contra as rows frescas e dispara
+// as notificações configuradas. Reaproveita a mesma storage sqlite do
+// histórico pra resolver changed/crossed_above/crossed_below.
+func runWatchCmd(configPath string, interval time.Duration, dbPath, contains string, dryRun bool) {
+	rules, err := watch.LoadConfig(configPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		log.Fatalf("erro criando pasta da storage: %v", err)
+	}
+	store, err := storage.NewSQLiteStore(dbPath)
+	if err != nil {
+		log.Fatalf("erro abrindo storage: %v", err)
+	}
+	defer store.Close()
+
+	client := scraper.NewClient()
+
+	if dryRun {
+		runWatchTick(client, store, rules, contains, true)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		runWatchTick(client, store, rules, contains, false)
+		<-ticker.C
+	}
+}
+
+// runWatchTick roda um ciclo: scrape, avalia regras contra o snapshot
+// anterior na storage, notifica (ou só imprime, em dry-run) e grava o
+// snapshot novo pra servir de "anterior" no próximo tick.
+func runWatchTick(client *req.Client, store *storage.SQLiteStore, rules []watch.Rule, contains string, dryRun bool) {
+	payload, err := scraper.Run(client, contains)
+	if err != nil {
+		log.Printf("erro no poll do watch: %v", err)
+		return
+	}
+
+	matches, err := watch.Evaluate(rules, payload.Data, store)
+	if err != nil {
+		log.Printf("erro avaliando regras de watch: %v", err)
+		return
+	}
+
+	for _, match := range matches {
+		if dryRun {
+			fmt.Printf("[dry-run] regra %q casaria para %s (notificaria: %v)\n", match.Rule.ID, match.Row.Ticker, match.Rule.Notify)
+			continue
+		}
+		for _, spec := range match.Rule.Notify {
+			if err := watch.Notify(match, spec); err != nil {
+				log.Printf("erro notificando %q pra regra %q: %v", spec, match.Rule.ID, err)
+			}
+		}
+	}
+
+	if dryRun {
+		return
+	}
+
+	snapshots := make([]storage.Snapshot, 0, len(payload.Data))
+	for _, row := range payload.Data {
+		snapshots = append(snapshots, storage.Snapshot{
+			Ticker:            row.Ticker,
+			LastPriceChangeAt: payload.Meta.LastPriceChangeAt,
+			PrecoAtual:        row.PrecoAtual,
+			YieldAtual:        row.YieldAtual,
+			ObservedAt:        payload.Meta.LastRunAt,
+		})
+	}
+	if err := store.RecordSnapshots(snapshots); err != nil {
+		log.Printf("erro gravando snapshots do watch: %v", err)
+	}
+}
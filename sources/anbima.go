@@ -0,0 +1,127 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+)
+
+// A Anbima publica o arquivo diário de Mercado Secundário de Títulos
+// Públicos num caminho com a data do pregão no formato aammdd.
+const urlAnbimaTemplate = "https://www.anbima.com.br/informacoes/merc-sec/arqs/ms%s.txt"
+
+// anbimaSource normaliza o arquivo de mercado secundário da Anbima pro
+// mesmo formato de DataRow do TD, pra permitir comparar o preço oficial do
+// TD com o preço marcado a mercado no secundário no mesmo dia.
+type anbimaSource struct{}
+
+func (anbimaSource) Name() string { return "anbima" }
+
+func (anbimaSource) Fetch(ctx context.Context, client *req.Client) ([]scraper.DataRow, scraper.Meta, error) {
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	today := time.Now()
+	if loc != nil {
+		today = today.In(loc)
+	}
+	url := fmt.Sprintf(urlAnbimaTemplate, today.Format("060102"))
+
+	resp, err := client.R().Get(url)
+	if err != nil {
+		return nil, scraper.Meta{}, fmt.Errorf("erro ao baixar arquivo da Anbima: %w", err)
+	}
+	if !resp.IsSuccessState() {
+		return nil, scraper.Meta{}, fmt.Errorf("HTTP %d ao baixar arquivo da Anbima (%s)", resp.GetStatusCode(), url)
+	}
+
+	rows, err := parseAnbimaSecundario(resp.String())
+	if err != nil {
+		return nil, scraper.Meta{}, fmt.Errorf("erro ao parsear arquivo da Anbima: %w", err)
+	}
+
+	return rows, scraper.Meta{
+		SourceURL: url,
+		LastRunAt: today.Truncate(time.Second).Format(time.RFC3339),
+	}, nil
+}
+
+// parseAnbimaSecundario normaliza o arquivo "Mercado Secundário de Títulos
+// Públicos" em DataRow. O arquivo é texto delimitado por '@', com uma linha
+// de cabeçalho; as colunas que importam aqui são o código do título (igual
+// à base do ticker do TD: LTN, LFT, NTN-B, NTN-F, ...), o vencimento
+// (yyyy-mm-dd) e o PU médio praticado no secundário, que vira o "preço".
+// Nenhum yield é lido nem estimado, então YieldAtual fica zerado nessa fonte.
+func parseAnbimaSecundario(text string) ([]scraper.DataRow, error) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("arquivo da Anbima vazio ou sem linhas de dados")
+	}
+
+	headers := strings.Split(lines[0], "@")
+	idxCodigo := indexOfColumn(headers, "Titulo")
+	idxVenc := indexOfColumn(headers, "Data Vencimento")
+	idxPU := indexOfColumn(headers, "PU Media")
+
+	if idxCodigo < 0 || idxVenc < 0 || idxPU < 0 {
+		return nil, fmt.Errorf("não achei colunas esperadas no arquivo da Anbima. headers=%v", headers)
+	}
+
+	var out []scraper.DataRow
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "@")
+		maxIdx := idxCodigo
+		for _, i := range []int{idxVenc, idxPU} {
+			if i > maxIdx {
+				maxIdx = i
+			}
+		}
+		if maxIdx >= len(fields) {
+			continue
+		}
+
+		codigo := strings.TrimSpace(fields[idxCodigo])
+		venc := normalizeAnbimaDate(strings.TrimSpace(fields[idxVenc]))
+		if codigo == "" || venc == "" {
+			continue
+		}
+
+		pu, err := scraper.ParseBRL(strings.TrimSpace(fields[idxPU]))
+		if err != nil {
+			continue
+		}
+
+		out = append(out, scraper.DataRow{
+			Ticker:     fmt.Sprintf("%s %s", codigo, venc),
+			PrecoAtual: pu,
+		})
+	}
+	return out, nil
+}
+
+func indexOfColumn(headers []string, want string) int {
+	for i, h := range headers {
+		if strings.EqualFold(strings.TrimSpace(h), want) {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeAnbimaDate aceita tanto yyyy-mm-dd quanto yyyymmdd (como vem em
+// alguns layouts da Anbima) e devolve sempre yyyy-mm-dd.
+func normalizeAnbimaDate(s string) string {
+	if strings.Count(s, "-") == 2 {
+		return s
+	}
+	if len(s) == 8 {
+		return fmt.Sprintf("%s-%s-%s", s[0:4], s[4:6], s[6:8])
+	}
+	return ""
+}
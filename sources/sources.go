@@ -0,0 +1,114 @@
+// Package sources define a interface comum a todo fetcher de dados de
+// títulos públicos (TD resgatar, TD comprar, Anbima secundário, ...) e sabe
+// mesclar os resultados de várias fontes num único Payload.
+package sources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/imroc/req/v3"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+)
+
+// Source busca e normaliza os dados de uma fonte num conjunto de DataRow,
+// mais o Meta específico dessa fonte (LastRunAt, LastPriceChangeAt, etc).
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, client *req.Client) ([]scraper.DataRow, scraper.Meta, error)
+}
+
+var registry = map[string]Source{}
+
+func register(s Source) {
+	registry[s.Name()] = s
+}
+
+func init() {
+	register(tdResgatarSource{})
+	register(tdComprarSource{})
+	register(anbimaSource{})
+}
+
+// Get devolve a Source registrada com esse nome.
+func Get(name string) (Source, bool) {
+	s, ok := registry[name]
+	return s, ok
+}
+
+// Names lista os nomes de todas as fontes registradas.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Merge busca em cada fonte de names, em sequência, reaproveitando o mesmo
+// client entre elas, e combina tudo num único Payload: Meta.Source vira a
+// lista de fontes consultadas e cada DataRow carrega de qual fonte veio.
+func Merge(ctx context.Context, client *req.Client, names []string) (scraper.Payload, error) {
+	var merged scraper.Payload
+	var sourceURLs []string
+
+	for _, name := range names {
+		src, ok := Get(name)
+		if !ok {
+			return scraper.Payload{}, fmt.Errorf("fonte desconhecida: %q (disponíveis: %v)", name, Names())
+		}
+
+		rows, meta, err := src.Fetch(ctx, client)
+		if err != nil {
+			return scraper.Payload{}, fmt.Errorf("fonte %s: %w", name, err)
+		}
+
+		for i := range rows {
+			rows[i].Source = name
+		}
+		merged.Data = append(merged.Data, rows...)
+		merged.Meta.Source = append(merged.Meta.Source, name)
+
+		if meta.SourceURL != "" {
+			sourceURLs = append(sourceURLs, meta.SourceURL)
+		}
+		if meta.LastRunAt > merged.Meta.LastRunAt {
+			merged.Meta.LastRunAt = meta.LastRunAt
+		}
+		if meta.LastPriceChangeAt != "" {
+			merged.Meta.LastPriceChangeAt = meta.LastPriceChangeAt
+		}
+	}
+
+	merged.Meta.SourceURL = strings.Join(sourceURLs, "; ")
+	merged.Meta.Rows = len(merged.Data)
+	return merged, nil
+}
+
+// FanOut busca em cada fonte de names, em sequência, e devolve um Payload
+// por fonte, já com Meta.Source/DataRow.Source preenchidos — pra quem quer
+// escrever um arquivo JSON separado por fonte em vez de mesclar tudo.
+func FanOut(ctx context.Context, client *req.Client, names []string) (map[string]scraper.Payload, error) {
+	out := make(map[string]scraper.Payload, len(names))
+	for _, name := range names {
+		src, ok := Get(name)
+		if !ok {
+			return nil, fmt.Errorf("fonte desconhecida: %q (disponíveis: %v)", name, Names())
+		}
+
+		rows, meta, err := src.Fetch(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("fonte %s: %w", name, err)
+		}
+		for i := range rows {
+			rows[i].Source = name
+		}
+		meta.Source = []string{name}
+		meta.Rows = len(rows)
+
+		out[name] = scraper.Payload{Meta: meta, Data: rows}
+	}
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package sources
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/imroc/req/v3"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+)
+
+// URL do CSV de compra, espelhando o de resgate no mesmo portal.
+const urlComprar = "https://www.tesourodireto.com.br/documents/d/guest/rendimento-comprar-csv?download=true"
+
+// tdComprarSource é a ponta compradora do mesmo CSV de rendimento do TD:
+// mesma planilha de "resgatar", mas com a coluna de preço de compra em vez
+// de resgate. Dá pra comparar o spread compra/venda do mesmo ticker.
+type tdComprarSource struct{}
+
+func (tdComprarSource) Name() string { return "td_comprar" }
+
+func (tdComprarSource) Fetch(ctx context.Context, client *req.Client) ([]scraper.DataRow, scraper.Meta, error) {
+	resp, err := client.R().
+		SetHeader("Referer", scraper.URL_RENDIMENTO_TITULOS).
+		Get(urlComprar)
+	if err != nil {
+		return nil, scraper.Meta{}, fmt.Errorf("erro ao baixar CSV de compra: %w", err)
+	}
+	if !resp.IsSuccessState() {
+		return nil, scraper.Meta{}, fmt.Errorf("HTTP %d ao baixar CSV de compra", resp.GetStatusCode())
+	}
+
+	rows, err := parseCompraCSV(resp.String())
+	if err != nil {
+		return nil, scraper.Meta{}, fmt.Errorf("erro ao parsear CSV de compra: %w", err)
+	}
+
+	data := make([]scraper.DataRow, 0, len(rows))
+	for _, r := range rows {
+		vencYMD := scraper.ParsePtBrDateToYMD(r.vencimento)
+		if vencYMD == "" {
+			continue
+		}
+		base := scraper.InferTickerBaseFromTituloTD(r.titulo)
+		data = append(data, scraper.DataRow{
+			Ticker:     fmt.Sprintf("%s %s", base, vencYMD),
+			PrecoAtual: r.preco,
+			YieldAtual: scraper.ParseYieldPercentToDecimal(r.rendimento),
+		})
+	}
+
+	return data, scraper.Meta{SourceURL: urlComprar}, nil
+}
+
+type compraRow struct {
+	titulo     string
+	rendimento string
+	preco      float64
+	vencimento string
+}
+
+func parseCompraCSV(csvText string) ([]compraRow, error) {
+	csvText = strings.TrimSpace(csvText)
+
+	rd := csv.NewReader(strings.NewReader(csvText))
+	rd.Comma = ';'
+	rd.FieldsPerRecord = -1
+
+	all, err := rd.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(all) < 2 {
+		return nil, errors.New("CSV de compra vazio ou sem linhas de dados")
+	}
+
+	headers := all[0]
+	if len(headers) == 0 {
+		return nil, errors.New("CSV de compra sem cabeçalho")
+	}
+	headers[0] = strings.TrimPrefix(headers[0], "\ufeff")
+
+	idxTitulo := scraper.FindHeader(headers, "Título")
+	idxRend := scraper.FindHeader(headers, "Rendimento anual do título")
+	idxPreco := scraper.FindHeader(headers, "Preço unitário de compra")
+	idxVenc := scraper.FindHeaderContains(headers, "Vencimento")
+
+	if idxTitulo < 0 || idxRend < 0 || idxPreco < 0 || idxVenc < 0 {
+		return nil, fmt.Errorf("não achei colunas esperadas no CSV de compra. headers=%v", headers)
+	}
+
+	var out []compraRow
+	for _, rec := range all[1:] {
+		if len(rec) == 0 {
+			continue
+		}
+		maxIdx := idxTitulo
+		for _, i := range []int{idxRend, idxPreco, idxVenc} {
+			if i > maxIdx {
+				maxIdx = i
+			}
+		}
+		if maxIdx >= len(rec) {
+			continue
+		}
+
+		titulo := strings.TrimSpace(rec[idxTitulo])
+		if titulo == "" {
+			continue
+		}
+
+		preco, err := scraper.ParseBRL(strings.TrimSpace(rec[idxPreco]))
+		if err != nil {
+			preco = 0
+		}
+
+		out = append(out, compraRow{
+			titulo:     titulo,
+			rendimento: strings.TrimSpace(rec[idxRend]),
+			preco:      preco,
+			vencimento: strings.TrimSpace(rec[idxVenc]),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,23 @@
+package sources
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+)
+
+// tdResgatarSource é a fonte já existente: o CSV de resgate do Tesouro
+// Direto, via scraper.Run.
+type tdResgatarSource struct{}
+
+func (tdResgatarSource) Name() string { return "td_resgatar" }
+
+func (tdResgatarSource) Fetch(ctx context.Context, client *req.Client) ([]scraper.DataRow, scraper.Meta, error) {
+	payload, err := scraper.Run(client, "")
+	if err != nil {
+		return nil, scraper.Meta{}, err
+	}
+	return payload.Data, payload.Meta, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // driver puro-Go, sem CGO
+)
+
+// SQLiteStore é a implementação padrão de Store, baseada em
+// modernc.org/sqlite pra não depender de CGO no build/deploy.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore abre (criando se preciso) o banco em path e garante o
+// schema da tabela de snapshots.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("erro abrindo sqlite em %s: %w", path, err)
+	}
+
+	const schema = `
+CREATE TABLE IF NOT EXISTS snapshots (
+	ticker               TEXT NOT NULL,
+	last_price_change_at TEXT NOT NULL,
+	preco_atual          REAL NOT NULL,
+	yield_atual          REAL NOT NULL,
+	observed_at          TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_snapshots_ticker_observed_at
+	ON snapshots (ticker, observed_at DESC);
+`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro criando schema: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) RecordSnapshots(snapshots []Snapshot) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+INSERT INTO snapshots (ticker, last_price_change_at, preco_atual, yield_atual, observed_at)
+VALUES (?, ?, ?, ?, ?)
+`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, snap := range snapshots {
+		observedAt, err := normalizeToUTCRFC3339(snap.ObservedAt)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("observed_at inválido pra ticker %s: %w", snap.Ticker, err)
+		}
+		if _, err := stmt.Exec(snap.Ticker, snap.LastPriceChangeAt, snap.PrecoAtual, snap.YieldAtual, observedAt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) LastSnapshot(ticker string) (Snapshot, bool, error) {
+	row := s.db.QueryRow(`
+SELECT ticker, last_price_change_at, preco_atual, yield_atual, observed_at
+FROM snapshots
+WHERE ticker = ?
+ORDER BY observed_at DESC
+LIMIT 1
+`, ticker)
+
+	var snap Snapshot
+	if err := row.Scan(&snap.Ticker, &snap.LastPriceChangeAt, &snap.PrecoAtual, &snap.YieldAtual, &snap.ObservedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return Snapshot{}, false, nil
+		}
+		return Snapshot{}, false, err
+	}
+	return snap, true, nil
+}
+
+func (s *SQLiteStore) History(ticker string, since time.Time) ([]Snapshot, error) {
+	rows, err := s.db.Query(`
+SELECT ticker, last_price_change_at, preco_atual, yield_atual, observed_at
+FROM snapshots
+WHERE ticker = ? AND observed_at >= ?
+ORDER BY observed_at DESC
+`, ticker, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Snapshot
+	for rows.Next() {
+		var snap Snapshot
+		if err := rows.Scan(&snap.Ticker, &snap.LastPriceChangeAt, &snap.PrecoAtual, &snap.YieldAtual, &snap.ObservedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, snap)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) Latest() ([]Snapshot, error) {
+	rows, err := s.db.Query(`
+SELECT ticker, last_price_change_at, preco_atual, yield_atual, MAX(observed_at) AS observed_at
+FROM snapshots
+GROUP BY ticker
+ORDER BY ticker
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Snapshot
+	for rows.Next() {
+		var snap Snapshot
+		if err := rows.Scan(&snap.Ticker, &snap.LastPriceChangeAt, &snap.PrecoAtual, &snap.YieldAtual, &snap.ObservedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, snap)
+	}
+	return out, rows.Err()
+}
+
+// normalizeToUTCRFC3339 reescreve um timestamp RFC3339 em UTC antes de
+// gravar. observed_at é TEXT e comparado/ordenado lexicograficamente nas
+// queries acima, o que só bate com a ordem cronológica real se todo mundo
+// estiver no mesmo offset; gravando sempre em UTC (sufixo "Z"), a comparação
+// de string volta a ser equivalente à comparação de instantes, mesmo quando
+// a fonte (ex.: LastRunAt em America/Sao_Paulo, -03:00) varia.
+func normalizeToUTCRFC3339(s string) (string, error) {
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "", err
+	}
+	return ts.UTC().Format(time.RFC3339), nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
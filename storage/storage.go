@@ -0,0 +1,96 @@
+// Package storage guarda o histórico de preços/yields observados pelo
+// scraper, pra permitir diff entre rodadas e consulta de séries temporais
+// por ticker. A interface Store é implementada por SQLiteStore; outras
+// implementações (ex.: um backend em memória pra testes) podem ser
+// adicionadas sem mexer em quem consome Store.
+package storage
+
+import "time"
+
+// Snapshot é o estado observado de um ticker em um dado momento.
+type Snapshot struct {
+	Ticker            string
+	LastPriceChangeAt string
+	PrecoAtual        float64
+	YieldAtual        float64
+	ObservedAt        string
+}
+
+// Store persiste e consulta snapshots por ticker.
+type Store interface {
+	// RecordSnapshots grava o estado observado de cada ticker na rodada atual.
+	RecordSnapshots(snapshots []Snapshot) error
+	// LastSnapshot devolve o snapshot mais recente gravado pra um ticker
+	// antes da rodada atual (ok=false se não há histórico ainda).
+	LastSnapshot(ticker string) (snap Snapshot, ok bool, err error)
+	// History devolve os snapshots de um ticker, mais recente primeiro.
+	// since zerado (time.Time{}) devolve todo o histórico.
+	History(ticker string, since time.Time) ([]Snapshot, error)
+	// Latest devolve o snapshot mais recente de cada ticker conhecido.
+	Latest() ([]Snapshot, error)
+	Close() error
+}
+
+// Change é o resultado de comparar o snapshot novo de um ticker contra o
+// último gravado: o que mudou e há quanto tempo não mudava.
+type Change struct {
+	Ticker            string  `json:"ticker"`
+	PrecoAtual        float64 `json:"preco_atual"`
+	PrecoAnterior     float64 `json:"preco_anterior"`
+	YieldAtual        float64 `json:"yield_atual"`
+	YieldAnterior     float64 `json:"yield_anterior"`
+	PrevPriceChangeAt string  `json:"prev_price_change_at"`
+	ElapsedSince      string  `json:"elapsed_since_last_change"`
+}
+
+// Diff compara snapshots novos contra o último valor gravado de cada
+// ticker em store, devolvendo só os que tiveram preço ou yield alterados.
+// Tickers sem histórico anterior não contam como mudança (é a primeira
+// observação, não há "anterior" pra comparar).
+func Diff(store Store, snapshots []Snapshot) ([]Change, error) {
+	var changes []Change
+	for _, snap := range snapshots {
+		prev, ok, err := store.LastSnapshot(snap.Ticker)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if prev.PrecoAtual == snap.PrecoAtual && prev.YieldAtual == snap.YieldAtual {
+			continue
+		}
+
+		// O valor de prev vale desde o snapshot mais antigo da sequência
+		// contínua (do mais recente pra trás) com o mesmo preço e yield.
+		history, err := store.History(snap.Ticker, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		lastChangeAt := prev.ObservedAt
+		for _, h := range history {
+			if h.PrecoAtual != prev.PrecoAtual || h.YieldAtual != prev.YieldAtual {
+				break
+			}
+			lastChangeAt = h.ObservedAt
+		}
+
+		elapsed := ""
+		if prevTS, err := time.Parse(time.RFC3339, lastChangeAt); err == nil {
+			if newTS, err := time.Parse(time.RFC3339, snap.ObservedAt); err == nil {
+				elapsed = newTS.Sub(prevTS).String()
+			}
+		}
+
+		changes = append(changes, Change{
+			Ticker:            snap.Ticker,
+			PrecoAtual:        snap.PrecoAtual,
+			PrecoAnterior:     prev.PrecoAtual,
+			YieldAtual:        snap.YieldAtual,
+			YieldAnterior:     prev.YieldAtual,
+			PrevPriceChangeAt: prev.LastPriceChangeAt,
+			ElapsedSince:      elapsed,
+		})
+	}
+	return changes, nil
+}
@@ -0,0 +1,94 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// notification é o corpo enviado a qualquer canal quando uma regra casa.
+type notification struct {
+	RuleID string             `json:"rule_id"`
+	Ticker string             `json:"ticker"`
+	Preco  float64            `json:"preco_atual"`
+	Yield  float64            `json:"yield_atual"`
+	When   string             `json:"when"`
+	At     string             `json:"at"`
+	Row    map[string]float64 `json:"row"`
+}
+
+// Notify dispara uma notificação de um Match, pelo canal descrito em spec.
+// spec é uma entrada de Rule.Notify, em um dos formatos: "stdout",
+// "file:<caminho>" ou "webhook:<url>".
+func Notify(match Match, spec string) error {
+	n := notification{
+		RuleID: match.Rule.ID,
+		Ticker: match.Row.Ticker,
+		Preco:  match.Row.PrecoAtual,
+		Yield:  match.Row.YieldAtual,
+		When:   match.Rule.When,
+		At:     time.Now().Format(time.RFC3339),
+		Row: map[string]float64{
+			"preco": match.Row.PrecoAtual,
+			"yield": match.Row.YieldAtual,
+		},
+	}
+
+	switch {
+	case spec == "stdout":
+		return notifyStdout(n)
+	case strings.HasPrefix(spec, "file:"):
+		return notifyFile(strings.TrimPrefix(spec, "file:"), n)
+	case strings.HasPrefix(spec, "webhook:"):
+		return notifyWebhook(strings.TrimPrefix(spec, "webhook:"), n)
+	default:
+		return fmt.Errorf("canal de notificação desconhecido: %q", spec)
+	}
+}
+
+func notifyStdout(n notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+func notifyFile(path string, n notification) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("erro abrindo %s pra notificação: %w", path, err)
+	}
+	defer f.Close()
+
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+func notifyWebhook(url string, n notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("erro chamando webhook %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s devolveu HTTP %d", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+// Package watch avalia regras de alerta (preço/yield/vencimento) contra os
+// DataRow vindos do scraper a cada poll, e dispara notificações quando uma
+// regra casa. As regras vêm de um arquivo YAML ou JSON de configuração.
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+	"github.com/FelipeQueirozC/realtime-TD/storage"
+)
+
+// Rule é uma condição de alerta: TickerPrefix restringe quais tickers ela
+// observa (ex.: "LTN"), When é a expressão avaliada a cada poll (ex.:
+// "preco > 1000") e Notify lista os canais a disparar quando ela casa.
+type Rule struct {
+	ID           string   `json:"id" yaml:"id"`
+	TickerPrefix string   `json:"ticker_prefix" yaml:"ticker_prefix"`
+	When         string   `json:"when" yaml:"when"`
+	Notify       []string `json:"notify" yaml:"notify"`
+}
+
+// LoadConfig lê um arquivo de regras em YAML ou JSON, decidindo o formato
+// pela extensão (.yaml/.yml vs .json).
+func LoadConfig(path string) ([]Rule, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro lendo config de watch %s: %w", path, err)
+	}
+
+	var rules []Rule
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(b, &rules); err != nil {
+			return nil, fmt.Errorf("erro parseando yaml de %s: %w", path, err)
+		}
+	case ".json":
+		if err := json.Unmarshal(b, &rules); err != nil {
+			return nil, fmt.Errorf("erro parseando json de %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("extensão desconhecida %q (use .yaml, .yml ou .json)", ext)
+	}
+
+	return rules, nil
+}
+
+// Match é uma regra que casou contra um DataRow num poll.
+type Match struct {
+	Rule Rule
+	Row  scraper.DataRow
+}
+
+// Evaluate roda todas as regras contra todas as rows da rodada atual,
+// usando store pra buscar o snapshot anterior de cada ticker (necessário
+// pros operadores changed/crossed_above/crossed_below). Devolve os matches
+// na ordem em que as regras casaram.
+func Evaluate(rules []Rule, rows []scraper.DataRow, store storage.Store) ([]Match, error) {
+	var matches []Match
+	for _, rule := range rules {
+		cond, err := parseCondition(rule.When)
+		if err != nil {
+			return nil, fmt.Errorf("regra %q: %w", rule.ID, err)
+		}
+
+		for _, row := range rows {
+			if rule.TickerPrefix != "" && !strings.HasPrefix(row.Ticker, rule.TickerPrefix) {
+				continue
+			}
+
+			var prev *storage.Snapshot
+			if store != nil {
+				if snap, ok, err := store.LastSnapshot(row.Ticker); err == nil && ok {
+					prev = &snap
+				}
+			}
+
+			ok, err := cond.eval(row, prev)
+			if err != nil {
+				return nil, fmt.Errorf("regra %q / ticker %q: %w", rule.ID, row.Ticker, err)
+			}
+			if ok {
+				matches = append(matches, Match{Rule: rule, Row: row})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// ===== Condições =====
+
+type operator string
+
+const (
+	opGT           operator = ">"
+	opLT           operator = "<"
+	opGTE          operator = ">="
+	opLTE          operator = "<="
+	opEQ           operator = "=="
+	opNEQ          operator = "!="
+	opChanged      operator = "changed"
+	opCrossedAbove operator = "crossed_above"
+	opCrossedBelow operator = "crossed_below"
+)
+
+type condition struct {
+	field string // "preco", "yield" ou "vencimento_dias"
+	op    operator
+	value float64 // não usado por "changed"
+}
+
+// parseCondition entende expressões como "preco > 1000", "yield <= 0.12",
+// "vencimento_dias < 30" ou, sem valor, "preco changed".
+func parseCondition(when string) (condition, error) {
+	fields := strings.Fields(strings.TrimSpace(when))
+	if len(fields) < 2 {
+		return condition{}, fmt.Errorf("expressão inválida: %q", when)
+	}
+
+	field := fields[0]
+	if field != "preco" && field != "yield" && field != "vencimento_dias" {
+		return condition{}, fmt.Errorf("campo desconhecido %q (use preco, yield ou vencimento_dias)", field)
+	}
+
+	op := operator(fields[1])
+	switch op {
+	case opChanged:
+		if len(fields) != 2 {
+			return condition{}, fmt.Errorf("operador changed não recebe valor: %q", when)
+		}
+		return condition{field: field, op: op}, nil
+	case opGT, opLT, opGTE, opLTE, opEQ, opNEQ, opCrossedAbove, opCrossedBelow:
+		if len(fields) != 3 {
+			return condition{}, fmt.Errorf("operador %s requer um valor: %q", op, when)
+		}
+		value, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return condition{}, fmt.Errorf("valor inválido em %q: %w", when, err)
+		}
+		return condition{field: field, op: op, value: value}, nil
+	default:
+		return condition{}, fmt.Errorf("operador desconhecido %q", op)
+	}
+}
+
+func (c condition) eval(row scraper.DataRow, prev *storage.Snapshot) (bool, error) {
+	current, ok := fieldValue(c.field, row)
+	if !ok {
+		return false, nil // ticker sem data de vencimento reconhecível, por exemplo
+	}
+
+	switch c.op {
+	case opGT:
+		return current > c.value, nil
+	case opLT:
+		return current < c.value, nil
+	case opGTE:
+		return current >= c.value, nil
+	case opLTE:
+		return current <= c.value, nil
+	case opEQ:
+		return current == c.value, nil
+	case opNEQ:
+		return current != c.value, nil
+	}
+
+	// changed/crossed_* precisam do valor anterior, que só existe pra
+	// preco e yield (é o que a storage guarda por snapshot).
+	prevValue, ok := prevFieldValue(c.field, prev)
+	if !ok {
+		return false, nil
+	}
+
+	switch c.op {
+	case opChanged:
+		return prevValue != current, nil
+	case opCrossedAbove:
+		return prevValue < c.value && current >= c.value, nil
+	case opCrossedBelow:
+		return prevValue > c.value && current <= c.value, nil
+	}
+
+	return false, fmt.Errorf("operador não tratado: %s", c.op)
+}
+
+func fieldValue(field string, row scraper.DataRow) (float64, bool) {
+	switch field {
+	case "preco":
+		return row.PrecoAtual, true
+	case "yield":
+		return row.YieldAtual, true
+	case "vencimento_dias":
+		dias, ok := vencimentoDias(row.Ticker)
+		return dias, ok
+	}
+	return 0, false
+}
+
+func prevFieldValue(field string, prev *storage.Snapshot) (float64, bool) {
+	if prev == nil {
+		return 0, false
+	}
+	switch field {
+	case "preco":
+		return prev.PrecoAtual, true
+	case "yield":
+		return prev.YieldAtual, true
+	default:
+		// vencimento_dias não é persistido por snapshot, então
+		// changed/crossed_* não se aplicam a ele.
+		return 0, false
+	}
+}
+
+// vencimentoDias extrai o sufixo "YYYY-MM-DD" do ticker (ex.: "LTN 2028-01-01")
+// e devolve quantos dias faltam até lá, a partir de agora.
+func vencimentoDias(ticker string) (float64, bool) {
+	parts := strings.Fields(ticker)
+	if len(parts) == 0 {
+		return 0, false
+	}
+	ymd := parts[len(parts)-1]
+
+	venc, err := time.Parse("2006-01-02", ymd)
+	if err != nil {
+		return 0, false
+	}
+
+	return venc.Sub(time.Now()).Hours() / 24, true
+}
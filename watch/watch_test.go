@@ -0,0 +1,160 @@
+package watch
+
+import (
+	"testing"
+
+	"github.com/FelipeQueirozC/realtime-TD/scraper"
+	"github.com/FelipeQueirozC/realtime-TD/storage"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		when    string
+		want    condition
+		wantErr bool
+	}{
+		{
+			name: "comparacao simples",
+			when: "preco > 1000",
+			want: condition{field: "preco", op: opGT, value: 1000},
+		},
+		{
+			name: "yield com valor decimal",
+			when: "yield <= 0.12",
+			want: condition{field: "yield", op: opLTE, value: 0.12},
+		},
+		{
+			name: "vencimento_dias",
+			when: "vencimento_dias < 30",
+			want: condition{field: "vencimento_dias", op: opLT, value: 30},
+		},
+		{
+			name: "changed sem valor",
+			when: "preco changed",
+			want: condition{field: "preco", op: opChanged},
+		},
+		{
+			name:    "campo desconhecido",
+			when:    "foo > 1",
+			wantErr: true,
+		},
+		{
+			name:    "operador desconhecido",
+			when:    "preco ~= 1",
+			wantErr: true,
+		},
+		{
+			name:    "changed com valor sobrando",
+			when:    "preco changed 1",
+			wantErr: true,
+		},
+		{
+			name:    "operador de comparacao sem valor",
+			when:    "preco >",
+			wantErr: true,
+		},
+		{
+			name:    "expressao vazia",
+			when:    "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCondition(tt.when)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCondition(%q) = %v, esperava erro", tt.when, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCondition(%q) erro inesperado: %v", tt.when, err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseCondition(%q) = %+v, want %+v", tt.when, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionEvalCrossed(t *testing.T) {
+	row := scraper.DataRow{Ticker: "LTN 2028-01-01", PrecoAtual: 1050}
+
+	tests := []struct {
+		name string
+		cond condition
+		prev *storage.Snapshot
+		want bool
+	}{
+		{
+			name: "crossed_above cruzou pra cima",
+			cond: condition{field: "preco", op: opCrossedAbove, value: 1000},
+			prev: &storage.Snapshot{PrecoAtual: 950},
+			want: true,
+		},
+		{
+			name: "crossed_above ja estava acima",
+			cond: condition{field: "preco", op: opCrossedAbove, value: 1000},
+			prev: &storage.Snapshot{PrecoAtual: 1010},
+			want: false,
+		},
+		{
+			name: "crossed_above sem snapshot anterior",
+			cond: condition{field: "preco", op: opCrossedAbove, value: 1000},
+			prev: nil,
+			want: false,
+		},
+		{
+			name: "crossed_below cruzou pra baixo",
+			cond: condition{field: "preco", op: opCrossedBelow, value: 1100},
+			prev: &storage.Snapshot{PrecoAtual: 1150},
+			want: true,
+		},
+		{
+			name: "crossed_below nao cruzou",
+			cond: condition{field: "preco", op: opCrossedBelow, value: 1000},
+			prev: &storage.Snapshot{PrecoAtual: 1150},
+			want: false,
+		},
+		{
+			name: "changed com valor diferente",
+			cond: condition{field: "preco", op: opChanged},
+			prev: &storage.Snapshot{PrecoAtual: 900},
+			want: true,
+		},
+		{
+			name: "changed com mesmo valor",
+			cond: condition{field: "preco", op: opChanged},
+			prev: &storage.Snapshot{PrecoAtual: 1050},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cond.eval(row, tt.prev)
+			if err != nil {
+				t.Fatalf("eval() erro inesperado: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionEvalVencimentoDiasSemTicker(t *testing.T) {
+	cond := condition{field: "vencimento_dias", op: opLT, value: 30}
+	row := scraper.DataRow{Ticker: "sem data reconhecivel"}
+
+	got, err := cond.eval(row, nil)
+	if err != nil {
+		t.Fatalf("eval() erro inesperado: %v", err)
+	}
+	if got {
+		t.Fatalf("eval() = true, want false pra ticker sem vencimento reconhecível")
+	}
+}